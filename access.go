@@ -0,0 +1,105 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// parseCIDRs parses a comma-separated list of CIDRs (e.g. "10.0.0.0/8,::1/128")
+// into *net.IPNet values. An empty string yields an empty, non-nil slice.
+func parseCIDRs(raw string) ([]*net.IPNet, error) {
+	nets := []*net.IPNet{}
+	if strings.TrimSpace(raw) == "" {
+		return nets, nil
+	}
+
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", field, err)
+		}
+		nets = append(nets, ipNet)
+	}
+
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP returns the client IP for r. If the immediate peer
+// (r.RemoteAddr) is in trustedProxies, the left-most address in
+// X-Forwarded-For is used instead, consistent with the ip_range gating
+// pattern used by other Prometheus-ecosystem exporters and plugins.
+func remoteIP(r *http.Request, trustedProxies []*net.IPNet) (net.IP, error) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	peer := net.ParseIP(host)
+	if peer == nil {
+		return nil, fmt.Errorf("could not parse remote address %q", r.RemoteAddr)
+	}
+
+	if len(trustedProxies) == 0 || !containsIP(trustedProxies, peer) {
+		return peer, nil
+	}
+
+	xff := r.Header.Get("X-Forwarded-For")
+	if xff == "" {
+		return peer, nil
+	}
+
+	first := strings.TrimSpace(strings.Split(xff, ",")[0])
+	if forwarded := net.ParseIP(first); forwarded != nil {
+		return forwarded, nil
+	}
+
+	return peer, nil
+}
+
+// allowListMiddleware rejects requests whose resolved remote IP does not
+// fall within allowedCIDRs with a 403. An empty allowedCIDRs allows every
+// request through unchanged.
+func allowListMiddleware(next http.HandlerFunc, allowedCIDRs, trustedProxies []*net.IPNet) http.HandlerFunc {
+	if len(allowedCIDRs) == 0 {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip, err := remoteIP(r, trustedProxies)
+		if err != nil || !containsIP(allowedCIDRs, ip) {
+			http.Error(w, "Forbidden.", http.StatusForbidden)
+			return
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseCIDRs(t *testing.T) {
+	nets, err := parseCIDRs(" 10.0.0.0/8 , ::1/128 ")
+	if err != nil {
+		t.Fatalf("parseCIDRs returned error: %v", err)
+	}
+	if len(nets) != 2 {
+		t.Fatalf("parseCIDRs returned %d nets, want 2", len(nets))
+	}
+
+	if _, err := parseCIDRs("not-a-cidr"); err == nil {
+		t.Fatal("expected an error for an invalid CIDR, got nil")
+	}
+
+	nets, err = parseCIDRs("  ")
+	if err != nil {
+		t.Fatalf("parseCIDRs(empty) returned error: %v", err)
+	}
+	if len(nets) != 0 {
+		t.Fatalf("parseCIDRs(empty) returned %d nets, want 0", len(nets))
+	}
+}
+
+func TestRemoteIP(t *testing.T) {
+	trusted, err := parseCIDRs("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("parseCIDRs returned error: %v", err)
+	}
+
+	t.Run("untrusted peer is used as-is", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/probe", nil)
+		r.RemoteAddr = "203.0.113.5:12345"
+		r.Header.Set("X-Forwarded-For", "198.51.100.9")
+
+		ip, err := remoteIP(r, trusted)
+		if err != nil {
+			t.Fatalf("remoteIP returned error: %v", err)
+		}
+		if ip.String() != "203.0.113.5" {
+			t.Errorf("remoteIP = %s, want 203.0.113.5", ip)
+		}
+	})
+
+	t.Run("trusted proxy defers to X-Forwarded-For", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/probe", nil)
+		r.RemoteAddr = "10.1.2.3:12345"
+		r.Header.Set("X-Forwarded-For", "198.51.100.9, 10.1.2.3")
+
+		ip, err := remoteIP(r, trusted)
+		if err != nil {
+			t.Fatalf("remoteIP returned error: %v", err)
+		}
+		if ip.String() != "198.51.100.9" {
+			t.Errorf("remoteIP = %s, want 198.51.100.9", ip)
+		}
+	})
+
+	t.Run("trusted proxy with no X-Forwarded-For falls back to peer", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/probe", nil)
+		r.RemoteAddr = "10.1.2.3:12345"
+
+		ip, err := remoteIP(r, trusted)
+		if err != nil {
+			t.Fatalf("remoteIP returned error: %v", err)
+		}
+		if ip.String() != "10.1.2.3" {
+			t.Errorf("remoteIP = %s, want 10.1.2.3", ip)
+		}
+	})
+
+	t.Run("unparseable remote address errors", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/probe", nil)
+		r.RemoteAddr = "not-an-address"
+
+		if _, err := remoteIP(r, trusted); err == nil {
+			t.Fatal("expected an error for an unparseable remote address, got nil")
+		}
+	})
+}
+
+func TestAllowListMiddleware(t *testing.T) {
+	allowed, err := parseCIDRs("203.0.113.0/24")
+	if err != nil {
+		t.Fatalf("parseCIDRs returned error: %v", err)
+	}
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	handler := allowListMiddleware(ok, allowed, nil)
+
+	allowedReq := httptest.NewRequest(http.MethodGet, "/probe", nil)
+	allowedReq.RemoteAddr = "203.0.113.5:12345"
+	allowedRec := httptest.NewRecorder()
+	handler(allowedRec, allowedReq)
+	if allowedRec.Code != http.StatusOK {
+		t.Errorf("allowed IP got status %d, want %d", allowedRec.Code, http.StatusOK)
+	}
+
+	deniedReq := httptest.NewRequest(http.MethodGet, "/probe", nil)
+	deniedReq.RemoteAddr = "198.51.100.5:12345"
+	deniedRec := httptest.NewRecorder()
+	handler(deniedRec, deniedReq)
+	if deniedRec.Code != http.StatusForbidden {
+		t.Errorf("denied IP got status %d, want %d", deniedRec.Code, http.StatusForbidden)
+	}
+}
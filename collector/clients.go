@@ -0,0 +1,62 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	// SHOW CLIENTS returns one row per connected client; on busy
+	// installations that can be tens of thousands of rows, so this
+	// collector is disabled by default.
+	registerCollector("clients", false, NewClientsCollector)
+}
+
+type clientsCollector struct {
+	logger log.Logger
+	desc   *prometheus.Desc
+}
+
+// NewClientsCollector exposes a count of client connections from
+// `SHOW CLIENTS`, broken down by database and state.
+func NewClientsCollector(logger log.Logger) (Collector, error) {
+	desc := prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "client", "connections"),
+		"Number of client connections, from pgbouncer SHOW CLIENTS.",
+		[]string{"database", "state"}, nil,
+	)
+
+	return &clientsCollector{logger: logger, desc: desc}, nil
+}
+
+func (c *clientsCollector) Update(sctx ScrapeContext, ch chan<- prometheus.Metric) error {
+	rows, err := queryRows(sctx.Context, sctx.DB, "SHOW CLIENTS")
+	if err != nil {
+		return err
+	}
+
+	counts := map[[2]string]float64{}
+	for _, row := range rows {
+		key := [2]string{row["database"], row["state"]}
+		counts[key]++
+	}
+
+	for key, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, count, key[0], key[1])
+	}
+
+	return nil
+}
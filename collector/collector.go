@@ -0,0 +1,150 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package collector registers one Collector per PgBouncer `SHOW ...` view,
+// following the node_exporter registration pattern: each collector's file in
+// this package calls registerCollector from an init() and the PgBouncerCollector
+// built by NewPgBouncerCollector runs whichever of them are enabled.
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// Namespace is the metric namespace shared by every collector in this
+// package.
+const Namespace = "pgbouncer"
+
+var (
+	scrapeDurationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "scrape_collector", "duration_seconds"),
+		"pgbouncer_exporter: Duration of a collector scrape.",
+		[]string{"collector"}, nil,
+	)
+	scrapeSuccessDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "scrape_collector", "success"),
+		"pgbouncer_exporter: Whether a collector succeeded.",
+		[]string{"collector"}, nil,
+	)
+)
+
+// ScrapeContext bundles everything a Collector needs to run a single scrape:
+// the database handle to query, a logger, and a context carrying the
+// per-scrape deadline derived from the Prometheus scrape timeout header.
+type ScrapeContext struct {
+	Context context.Context
+	DB      *sql.DB
+	Logger  log.Logger
+}
+
+// Collector is implemented by each SHOW-view collector in this package.
+type Collector interface {
+	// Update sends the metrics collected from one SHOW view to ch. It
+	// returns an error if the view could not be queried or parsed.
+	Update(sctx ScrapeContext, ch chan<- prometheus.Metric) error
+}
+
+var (
+	factories      = make(map[string]func(logger log.Logger) (Collector, error))
+	collectorState = make(map[string]*bool)
+)
+
+// registerCollector wires a collector factory into the registry and exposes
+// a --collector.<name> kingpin flag to enable or disable it.
+func registerCollector(name string, isDefaultEnabled bool, factory func(logger log.Logger) (Collector, error)) {
+	defaultState := "disabled"
+	if isDefaultEnabled {
+		defaultState = "enabled"
+	}
+
+	flagName := fmt.Sprintf("collector.%s", name)
+	flagHelp := fmt.Sprintf("Enable the %s collector (default: %s).", name, defaultState)
+	flag := kingpin.Flag(flagName, flagHelp).Default(fmt.Sprintf("%v", isDefaultEnabled)).Bool()
+
+	collectorState[name] = flag
+	factories[name] = factory
+}
+
+// PgBouncerCollector implements prometheus.Collector by fanning out to every
+// enabled SHOW-view Collector and reporting per-collector scrape duration and
+// success as meta-metrics.
+type PgBouncerCollector struct {
+	Collectors map[string]Collector
+	sctx       ScrapeContext
+}
+
+// NewPgBouncerCollector builds a PgBouncerCollector bound to sctx, containing
+// one instance of every collector enabled via --collector.<name> flags.
+func NewPgBouncerCollector(sctx ScrapeContext) (*PgBouncerCollector, error) {
+	collectors := make(map[string]Collector)
+	for name, enabled := range collectorState {
+		if !*enabled {
+			continue
+		}
+
+		collector, err := factories[name](log.With(sctx.Logger, "collector", name))
+		if err != nil {
+			return nil, fmt.Errorf("error creating collector %s: %w", name, err)
+		}
+		collectors[name] = collector
+	}
+
+	return &PgBouncerCollector{Collectors: collectors, sctx: sctx}, nil
+}
+
+// Describe implements prometheus.Collector.
+func (c PgBouncerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- scrapeDurationDesc
+	ch <- scrapeSuccessDesc
+}
+
+// Collect implements prometheus.Collector, running every enabled collector
+// concurrently against the bound ScrapeContext.
+func (c PgBouncerCollector) Collect(ch chan<- prometheus.Metric) {
+	wg := sync.WaitGroup{}
+	wg.Add(len(c.Collectors))
+	for name, coll := range c.Collectors {
+		go func(name string, coll Collector) {
+			execute(c.sctx, name, coll, ch)
+			wg.Done()
+		}(name, coll)
+	}
+	wg.Wait()
+}
+
+func execute(sctx ScrapeContext, name string, coll Collector, ch chan<- prometheus.Metric) {
+	start := time.Now()
+	err := coll.Update(sctx, ch)
+	duration := time.Since(start)
+
+	var success float64
+	if err != nil {
+		level.Error(sctx.Logger).Log("msg", "collector failed", "collector", name, "duration_seconds", duration.Seconds(), "err", err)
+		success = 0
+	} else {
+		level.Debug(sctx.Logger).Log("msg", "collector succeeded", "collector", name, "duration_seconds", duration.Seconds())
+		success = 1
+	}
+
+	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, duration.Seconds(), name)
+	ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, name)
+}
@@ -0,0 +1,139 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// fakeCollector is a minimal Collector used to exercise PgBouncerCollector
+// without depending on a real database or the kingpin-parsed flags that back
+// the collectors registered by this package's init() functions.
+type fakeCollector struct {
+	err error
+}
+
+func (c *fakeCollector) Update(sctx ScrapeContext, ch chan<- prometheus.Metric) error {
+	return c.err
+}
+
+// withFakeCollectors registers a fakeCollector under each name in collectors,
+// enabled or disabled as its value says ("broken" fails on Update), for the
+// duration of the test, restoring the previous registry state on cleanup so
+// it does not leak into other tests in this package.
+func withFakeCollectors(t *testing.T, collectors map[string]bool) {
+	t.Helper()
+
+	prevState := collectorState
+	prevFactories := factories
+	t.Cleanup(func() {
+		collectorState = prevState
+		factories = prevFactories
+	})
+
+	collectorState = make(map[string]*bool, len(collectors))
+	factories = make(map[string]func(logger log.Logger) (Collector, error), len(collectors))
+	for name, enabled := range collectors {
+		name, enabled := name, enabled
+		collectorState[name] = &enabled
+		factories[name] = func(logger log.Logger) (Collector, error) {
+			if name == "broken" {
+				return &fakeCollector{err: errors.New("boom")}, nil
+			}
+			return &fakeCollector{}, nil
+		}
+	}
+}
+
+func TestNewPgBouncerCollectorEnableDisable(t *testing.T) {
+	withFakeCollectors(t, map[string]bool{
+		"on":  true,
+		"off": false,
+	})
+
+	c, err := NewPgBouncerCollector(ScrapeContext{Logger: log.NewNopLogger()})
+	if err != nil {
+		t.Fatalf("NewPgBouncerCollector returned error: %v", err)
+	}
+
+	if _, ok := c.Collectors["on"]; !ok {
+		t.Error("expected the enabled collector \"on\" to be instantiated")
+	}
+	if _, ok := c.Collectors["off"]; ok {
+		t.Error("expected the disabled collector \"off\" to not be instantiated")
+	}
+}
+
+func TestPgBouncerCollectorCollectEmitsMetaMetrics(t *testing.T) {
+	withFakeCollectors(t, map[string]bool{
+		"ok":     true,
+		"broken": true,
+	})
+
+	c, err := NewPgBouncerCollector(ScrapeContext{Logger: log.NewNopLogger()})
+	if err != nil {
+		t.Fatalf("NewPgBouncerCollector returned error: %v", err)
+	}
+
+	ch := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	var metrics []prometheus.Metric
+	go func() {
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+		close(done)
+	}()
+
+	c.Collect(ch)
+	close(ch)
+	<-done
+
+	// One duration + one success metric per collector.
+	if want := 2 * len(c.Collectors); len(metrics) != want {
+		t.Fatalf("Collect emitted %d metrics, want %d", len(metrics), want)
+	}
+
+	successByCollector := map[string]float64{}
+	for _, m := range metrics {
+		if m.Desc() != scrapeSuccessDesc {
+			continue
+		}
+
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatalf("error writing metric: %v", err)
+		}
+
+		var collectorName string
+		for _, l := range pb.GetLabel() {
+			if l.GetName() == "collector" {
+				collectorName = l.GetValue()
+			}
+		}
+		successByCollector[collectorName] = pb.GetGauge().GetValue()
+	}
+
+	if successByCollector["ok"] != 1 {
+		t.Errorf("success metric for \"ok\" = %v, want 1", successByCollector["ok"])
+	}
+	if successByCollector["broken"] != 0 {
+		t.Errorf("success metric for \"broken\" = %v, want 0", successByCollector["broken"])
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"strconv"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const databasesSubsystem = "database"
+
+func init() {
+	registerCollector("databases", true, NewDatabasesCollector)
+}
+
+type databasesCollector struct {
+	logger log.Logger
+	descs  map[string]*prometheus.Desc
+}
+
+// NewDatabasesCollector exposes the per-database pool limits from
+// `SHOW DATABASES`.
+func NewDatabasesCollector(logger log.Logger) (Collector, error) {
+	descs := map[string]*prometheus.Desc{}
+	for _, name := range []string{"pool_size", "min_pool_size", "reserve_pool", "max_connections", "current_connections", "paused", "disabled"} {
+		descs[name] = prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, databasesSubsystem, name),
+			"Generated from pgbouncer SHOW DATABASES: "+name,
+			[]string{"database"}, nil,
+		)
+	}
+
+	return &databasesCollector{logger: logger, descs: descs}, nil
+}
+
+func (c *databasesCollector) Update(sctx ScrapeContext, ch chan<- prometheus.Metric) error {
+	rows, err := queryRows(sctx.Context, sctx.DB, "SHOW DATABASES")
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		database := row["name"]
+		for name, desc := range c.descs {
+			value, ok := row[name]
+			if !ok {
+				continue
+			}
+
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, f, database)
+		}
+	}
+
+	return nil
+}
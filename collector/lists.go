@@ -0,0 +1,60 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"strconv"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector("lists", true, NewListsCollector)
+}
+
+type listsCollector struct {
+	logger log.Logger
+	desc   *prometheus.Desc
+}
+
+// NewListsCollector exposes the global object counts from `SHOW LISTS`, one
+// gauge per list item (databases, users, pools, free_clients, ...).
+func NewListsCollector(logger log.Logger) (Collector, error) {
+	desc := prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "", "lists"),
+		"Generated from pgbouncer SHOW LISTS: items",
+		[]string{"list"}, nil,
+	)
+
+	return &listsCollector{logger: logger, desc: desc}, nil
+}
+
+func (c *listsCollector) Update(sctx ScrapeContext, ch chan<- prometheus.Metric) error {
+	rows, err := queryRows(sctx.Context, sctx.DB, "SHOW LISTS")
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		f, err := strconv.ParseFloat(row["items"], 64)
+		if err != nil {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, f, row["list"])
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"strconv"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector("mem", false, NewMemCollector)
+}
+
+type memCollector struct {
+	logger log.Logger
+	descs  map[string]*prometheus.Desc
+}
+
+// NewMemCollector exposes the internal allocator statistics from
+// `SHOW MEM`, useful for diagnosing PgBouncer memory growth.
+func NewMemCollector(logger log.Logger) (Collector, error) {
+	descs := map[string]*prometheus.Desc{}
+	for _, name := range []string{"size", "used", "free", "count"} {
+		descs[name] = prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "mem", name+"_bytes"),
+			"Generated from pgbouncer SHOW MEM: "+name,
+			[]string{"name"}, nil,
+		)
+	}
+
+	return &memCollector{logger: logger, descs: descs}, nil
+}
+
+func (c *memCollector) Update(sctx ScrapeContext, ch chan<- prometheus.Metric) error {
+	rows, err := queryRows(sctx.Context, sctx.DB, "SHOW MEM")
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		name := row["name"]
+		for metric, desc := range c.descs {
+			value, ok := row[metric]
+			if !ok {
+				continue
+			}
+
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, f, name)
+		}
+	}
+
+	return nil
+}
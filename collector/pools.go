@@ -0,0 +1,77 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"strconv"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const poolsSubsystem = "pools"
+
+func init() {
+	registerCollector("pools", true, NewPoolsCollector)
+}
+
+type poolsCollector struct {
+	logger log.Logger
+	descs  map[string]*prometheus.Desc
+}
+
+// NewPoolsCollector exposes the per-database/user pool gauges from
+// `SHOW POOLS`.
+func NewPoolsCollector(logger log.Logger) (Collector, error) {
+	descs := map[string]*prometheus.Desc{}
+	for _, name := range []string{
+		"cl_active", "cl_waiting", "cl_cancel_req",
+		"sv_active", "sv_idle", "sv_used", "sv_tested", "sv_login",
+		"maxwait", "maxwait_us",
+	} {
+		descs[name] = prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, poolsSubsystem, name),
+			"Generated from pgbouncer SHOW POOLS: "+name,
+			[]string{"database", "user", "pool_mode"}, nil,
+		)
+	}
+
+	return &poolsCollector{logger: logger, descs: descs}, nil
+}
+
+func (c *poolsCollector) Update(sctx ScrapeContext, ch chan<- prometheus.Metric) error {
+	rows, err := queryRows(sctx.Context, sctx.DB, "SHOW POOLS")
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		labels := []string{row["database"], row["user"], row["pool_mode"]}
+		for name, desc := range c.descs {
+			value, ok := row[name]
+			if !ok {
+				continue
+			}
+
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, f, labels...)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+)
+
+// queryRows runs a PgBouncer SHOW command and scans every row into a
+// column-name-keyed map. PgBouncer's SHOW output columns vary across
+// versions, so collectors look up the columns they know about by name and
+// silently skip ones that are absent rather than failing the whole scrape.
+func queryRows(ctx context.Context, db *sql.DB, show string) ([]map[string]string, error) {
+	rows, err := db.QueryContext(ctx, show)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []map[string]string
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		scanArgs := make([]interface{}, len(columns))
+		for i := range values {
+			scanArgs[i] = &values[i]
+		}
+
+		if err := rows.Scan(scanArgs...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]string, len(columns))
+		for i, col := range columns {
+			row[col] = values[i].String
+		}
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
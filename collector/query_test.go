@@ -0,0 +1,91 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestQueryRows(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"database", "total_xact_count"}).
+		AddRow("pgbouncer", "42").
+		AddRow("other", nil)
+	mock.ExpectQuery("SHOW STATS").WillReturnRows(rows)
+
+	got, err := queryRows(context.Background(), db, "SHOW STATS")
+	if err != nil {
+		t.Fatalf("queryRows returned error: %v", err)
+	}
+
+	want := []map[string]string{
+		{"database": "pgbouncer", "total_xact_count": "42"},
+		{"database": "other", "total_xact_count": ""},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("queryRows returned %d rows, want %d", len(got), len(want))
+	}
+	for i := range want {
+		for col, value := range want[i] {
+			if got[i][col] != value {
+				t.Errorf("row %d column %q = %q, want %q", i, col, got[i][col], value)
+			}
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sqlmock expectations: %v", err)
+	}
+}
+
+func TestQueryRowsQueryError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SHOW STATS").WillReturnError(errors.New("connection reset"))
+
+	if _, err := queryRows(context.Background(), db, "SHOW STATS"); err == nil {
+		t.Fatal("expected an error when the underlying query fails, got nil")
+	}
+}
+
+func TestQueryRowsNoRows(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SHOW STATS").WillReturnRows(sqlmock.NewRows([]string{"database"}))
+
+	got, err := queryRows(context.Background(), db, "SHOW STATS")
+	if err != nil {
+		t.Fatalf("queryRows returned error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("queryRows returned %d rows for an empty result set, want 0", len(got))
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	// SHOW SERVERS returns one row per backend connection; like the
+	// clients collector, this is disabled by default on busy installs.
+	registerCollector("servers", false, NewServersCollector)
+}
+
+type serversCollector struct {
+	logger log.Logger
+	desc   *prometheus.Desc
+}
+
+// NewServersCollector exposes a count of server connections from
+// `SHOW SERVERS`, broken down by database and state.
+func NewServersCollector(logger log.Logger) (Collector, error) {
+	desc := prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "server", "connections"),
+		"Number of server connections, from pgbouncer SHOW SERVERS.",
+		[]string{"database", "state"}, nil,
+	)
+
+	return &serversCollector{logger: logger, desc: desc}, nil
+}
+
+func (c *serversCollector) Update(sctx ScrapeContext, ch chan<- prometheus.Metric) error {
+	rows, err := queryRows(sctx.Context, sctx.DB, "SHOW SERVERS")
+	if err != nil {
+		return err
+	}
+
+	counts := map[[2]string]float64{}
+	for _, row := range rows {
+		key := [2]string{row["database"], row["state"]}
+		counts[key]++
+	}
+
+	for key, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, count, key[0], key[1])
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const statsSubsystem = "stat"
+
+func init() {
+	registerCollector("stats", true, NewStatsCollector)
+}
+
+type statsCollector struct {
+	logger log.Logger
+	descs  map[string]*prometheus.Desc
+}
+
+// NewStatsCollector exposes the per-database counters from `SHOW STATS`.
+func NewStatsCollector(logger log.Logger) (Collector, error) {
+	descs := map[string]*prometheus.Desc{}
+	for _, name := range []string{
+		"total_xact_count", "total_query_count", "total_received", "total_sent",
+		"total_xact_time", "total_query_time", "total_wait_time",
+		"avg_xact_count", "avg_query_count", "avg_recv", "avg_sent",
+		"avg_xact_time", "avg_query_time", "avg_wait_time",
+	} {
+		descs[name] = prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, statsSubsystem, name),
+			"Generated from pgbouncer SHOW STATS: "+name,
+			[]string{"database"}, nil,
+		)
+	}
+
+	return &statsCollector{logger: logger, descs: descs}, nil
+}
+
+func (c *statsCollector) Update(sctx ScrapeContext, ch chan<- prometheus.Metric) error {
+	rows, err := queryRows(sctx.Context, sctx.DB, "SHOW STATS")
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		database := row["database"]
+		for name, desc := range c.descs {
+			value, ok := row[name]
+			if !ok {
+				continue
+			}
+
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+
+			valueType := prometheus.CounterValue
+			if strings.HasPrefix(name, "avg_") {
+				valueType = prometheus.GaugeValue
+			}
+
+			ch <- prometheus.MustNewConstMetric(desc, valueType, f, database)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// AuthModule describes how to obtain credentials for a probed target.
+// Exactly one of the type-specific fields is populated, selected by Type.
+type AuthModule struct {
+	Type     string          `yaml:"type"`
+	UserPass *UserPassModule `yaml:"userpass,omitempty"`
+	Env      *EnvModule      `yaml:"env,omitempty"`
+	File     *FileModule     `yaml:"file,omitempty"`
+	Exec     *ExecModule     `yaml:"exec,omitempty"`
+}
+
+// UserPassModule is a plain username/password credential pair written
+// directly into the config file.
+type UserPassModule struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// EnvModule reads the credential pair from environment variables, so the
+// config file itself can be committed without secrets.
+type EnvModule struct {
+	UsernameEnv string `yaml:"username_env"`
+	PasswordEnv string `yaml:"password_env"`
+}
+
+// FileModule reads a "username:password" pair from a file, e.g. a mounted
+// Kubernetes secret.
+type FileModule struct {
+	Path string `yaml:"path"`
+}
+
+// ExecModule runs an external command and reads a "username:password" pair
+// from its first line of stdout, for integration with external secret
+// managers.
+type ExecModule struct {
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args"`
+}
+
+// credentials resolves the module to a concrete username/password pair.
+func (m AuthModule) credentials() (string, string, error) {
+	switch m.Type {
+	case "userpass":
+		if m.UserPass == nil {
+			return "", "", fmt.Errorf("auth module type userpass requires a userpass block")
+		}
+		return m.UserPass.Username, m.UserPass.Password, nil
+
+	case "env":
+		if m.Env == nil {
+			return "", "", fmt.Errorf("auth module type env requires an env block")
+		}
+		return os.Getenv(m.Env.UsernameEnv), os.Getenv(m.Env.PasswordEnv), nil
+
+	case "file":
+		if m.File == nil {
+			return "", "", fmt.Errorf("auth module type file requires a file block")
+		}
+		return readUserPassFile(m.File.Path)
+
+	case "exec":
+		if m.Exec == nil {
+			return "", "", fmt.Errorf("auth module type exec requires an exec block")
+		}
+		return execUserPass(m.Exec.Command, m.Exec.Args)
+
+	default:
+		return "", "", fmt.Errorf("unknown auth module type %q", m.Type)
+	}
+}
+
+func readUserPassFile(path string) (string, string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("error reading auth module file %q: %w", path, err)
+	}
+
+	return splitUserPass(string(data))
+}
+
+func execUserPass(command string, args []string) (string, string, error) {
+	var out bytes.Buffer
+	cmd := exec.Command(command, args...)
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		return "", "", fmt.Errorf("error running exec auth module %q: %w", command, err)
+	}
+
+	return splitUserPass(out.String())
+}
+
+func splitUserPass(raw string) (string, string, error) {
+	line := strings.TrimSpace(strings.SplitN(raw, "\n", 2)[0])
+	parts := strings.SplitN(line, ":", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("expected \"username:password\", got %q", line)
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// Target is a named PgBouncer instance that can be probed by name instead of
+// by DSN, e.g. `/probe?target=prod-east`.
+type Target struct {
+	Address string `yaml:"address"`
+}
+
+// Config is the top-level structure of the --config.file.
+type Config struct {
+	AuthModules map[string]AuthModule `yaml:"auth_modules"`
+	Targets     map[string]Target     `yaml:"targets"`
+}
+
+// LoadConfig reads and parses the YAML config file at path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file %q: %w", path, err)
+	}
+
+	cfg := &Config{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("error parsing config file %q: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// ResolveDSN turns a probe target and an optional auth module name into a
+// connection string. target is first looked up in cfg.Targets so callers can
+// pass a logical name instead of a full host:port; if it is not found it is
+// used verbatim (a literal host:port or DSN). A target that is already a DSN
+// (contains "://", e.g. the --pgBouncer.connectionString default) is
+// returned unchanged, since it is already a complete connection string.
+// authModuleName, when set, supplies the credentials layered on top of the
+// resolved address.
+func (cfg *Config) ResolveDSN(target, authModuleName string) (string, error) {
+	if strings.Contains(target, "://") {
+		return target, nil
+	}
+
+	address := target
+	if t, ok := cfg.Targets[target]; ok {
+		address = t.Address
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		Host:     address,
+		Path:     "/pgbouncer",
+		RawQuery: "sslmode=disable",
+	}
+
+	if authModuleName == "" {
+		return dsn.String(), nil
+	}
+
+	authModule, ok := cfg.AuthModules[authModuleName]
+	if !ok {
+		return "", fmt.Errorf("unknown auth_module %q", authModuleName)
+	}
+
+	username, password, err := authModule.credentials()
+	if err != nil {
+		return "", fmt.Errorf("error resolving auth_module %q: %w", authModuleName, err)
+	}
+
+	dsn.User = url.UserPassword(username, password)
+
+	return dsn.String(), nil
+}
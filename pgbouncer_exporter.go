@@ -14,19 +14,21 @@
 package main
 
 import (
+	"database/sql"
 	"fmt"
 	"net/http"
 	"os"
-	"strings"
 
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/promlog"
 	"github.com/prometheus/common/promlog/flag"
 	"github.com/prometheus/common/version"
+	"github.com/prometheus/exporter-toolkit/web"
 	"gopkg.in/alecthomas/kingpin.v2"
+
+	_ "github.com/lib/pq"
 )
 
 const (
@@ -45,27 +47,6 @@ const (
 	</html>`
 )
 
-func BasicAuth(handler http.HandlerFunc) http.HandlerFunc {
-	return func(rw http.ResponseWriter, rq *http.Request) {
-		u, p, ok := rq.BasicAuth()
-		if !ok || len(strings.TrimSpace(u)) < 1 || len(strings.TrimSpace(p)) < 1 {
-			http.Error(rw, "Unauthorized.", http.StatusUnauthorized)
-			return
-		}
-
-		// This is a dummy check for credentials.
-		if u != os.Getenv("BASIC_AUTH_USER") || p != os.Getenv("BASIC_AUTH_PASS") {
-			http.Error(rw, "Unauthorized.", http.StatusUnauthorized)
-			return
-		}
-
-		// If required, Context could be updated to include authentication
-		// related data so that it could be used in consequent steps.
-		handler(rw, rq)
-	}
-}
-
-
 func main() {
 	const pidFileHelpText = `Path to PgBouncer pid file.
 
@@ -80,10 +61,18 @@ func main() {
 	flag.AddFlags(kingpin.CommandLine, promlogConfig)
 
 	var (
-		connectionStringPointer = kingpin.Flag("pgBouncer.connectionString", "Connection string for accessing pgBouncer.").Default("postgres://postgres:@localhost:6543/pgbouncer?sslmode=disable").Envar("PGBOUNCER_URL").String()
-		listenPort     = kingpin.Flag("web.listen-port", "Port to listen on for web interface and telemetry.").Default("9584").Envar("PORT").String()
+		connectionStringPointer = kingpin.Flag("pgBouncer.connectionString", "Connection string or target name for accessing pgBouncer. Resolved the same way as the probe endpoint's target parameter.").Default("postgres://postgres:@localhost:6543/pgbouncer?sslmode=disable").Envar("PGBOUNCER_URL").String()
+		authModule              = kingpin.Flag("pgBouncer.auth-module", "auth_module from --config.file used to resolve credentials for --pgBouncer.connectionString.").Default("").String()
+		listenPort              = kingpin.Flag("web.listen-port", "Port to listen on for web interface and telemetry.").Default("9584").Envar("PORT").String()
 		metricsPath             = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").String()
+		probePath               = kingpin.Flag("web.probe-path", "Path under which to expose the probe endpoint for scraping other targets.").Default("/probe").String()
 		pidFilePath             = kingpin.Flag("pgBouncer.pid-file", pidFileHelpText).Default("").String()
+		configFile              = kingpin.Flag("config.file", "Path to config file with auth modules and targets, used by both --pgBouncer.connectionString and the probe endpoint.").Default("").String()
+		webConfigFile           = kingpin.Flag("web.config.file", "Path to config yaml file that can enable TLS or authentication.").Default("").String()
+		allowedCIDRs            = kingpin.Flag("web.allowed-cidrs", "Comma-separated list of CIDRs allowed to reach the web server. Empty allows all.").Default("").String()
+		trustedProxyCIDRs       = kingpin.Flag("web.trusted-proxy-cidrs", "Comma-separated list of CIDRs trusted to set X-Forwarded-For.").Default("").String()
+		probeRateLimit          = kingpin.Flag("web.probe-rate-limit", "Maximum sustained /probe requests per second, per remote IP. 0 disables rate limiting.").Default("0").Float64()
+		probeRateBurst          = kingpin.Flag("web.probe-rate-burst", "Burst size for --web.probe-rate-limit.").Default("1").Int()
 	)
 
 	kingpin.Version(version.Print("pgbouncer_exporter"))
@@ -92,14 +81,46 @@ func main() {
 
 	logger := promlog.New(promlogConfig)
 
-	reg := prometheus.NewRegistry()
+	var cfg Config
+	if *configFile != "" {
+		loaded, err := LoadConfig(*configFile)
+		if err != nil {
+			level.Error(logger).Log("msg", "Error loading config file", "err", err)
+			os.Exit(1)
+		}
+		cfg = *loaded
+	}
+
+	dsn, err := cfg.ResolveDSN(*connectionStringPointer, *authModule)
+	if err != nil {
+		level.Error(logger).Log("msg", "Error resolving --pgBouncer.connectionString", "err", err)
+		os.Exit(1)
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		level.Error(logger).Log("msg", "Error opening connection to pgBouncer", "err", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	allowedNets, err := parseCIDRs(*allowedCIDRs)
+	if err != nil {
+		level.Error(logger).Log("msg", "Error parsing --web.allowed-cidrs", "err", err)
+		os.Exit(1)
+	}
+	trustedProxyNets, err := parseCIDRs(*trustedProxyCIDRs)
+	if err != nil {
+		level.Error(logger).Log("msg", "Error parsing --web.trusted-proxy-cidrs", "err", err)
+		os.Exit(1)
+	}
 
-	connectionString := *connectionStringPointer
-	exporter := NewExporter(connectionString, namespace, logger)
+	var probeLimiter *perIPRateLimiter
+	if *probeRateLimit > 0 {
+		probeLimiter = newPerIPRateLimiter(*probeRateLimit, *probeRateBurst)
+	}
 
-	reg.MustRegister(exporter)
-	reg.MustRegister(version.NewCollector("pgbouncer_exporter"))
-	handler := promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+	handler := newHandler(db, logger)
 
 	level.Info(logger).Log("msg", "Starting pgbouncer_exporter", "version", version.Info())
 	level.Info(logger).Log("msg", "Build context", "build_context", version.BuildContext())
@@ -114,19 +135,19 @@ func main() {
 		prometheus.MustRegister(procExporter)
 	}
 
-	http.HandleFunc(*metricsPath, BasicAuth(func(w http.ResponseWriter, r *http.Request) {
-		handler.ServeHTTP(w, r)
-	}))
-	http.HandleFunc("/", BasicAuth(func(w http.ResponseWriter, r *http.Request) {
+	http.HandleFunc(*metricsPath, allowListMiddleware(handler.ServeHTTP, allowedNets, trustedProxyNets))
+	http.HandleFunc("/", allowListMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(fmt.Sprintf(indexHTML, *metricsPath)))
-	}))
-
+	}, allowedNets, trustedProxyNets))
+	http.HandleFunc(*probePath, allowListMiddleware(rateLimitMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		probeHandler(w, r, logger, &cfg)
+	}, probeLimiter, trustedProxyNets), allowedNets, trustedProxyNets))
 
-	var listenAddress string
-	listenAddress = ":" + *listenPort
+	listenAddress := ":" + *listenPort
+	server := &http.Server{Addr: listenAddress}
 
 	level.Info(logger).Log("msg", "Listening on", "address", listenAddress)
-	if err := http.ListenAndServe(listenAddress, nil); err != nil {
+	if err := web.ListenAndServe(server, *webConfigFile, logger); err != nil {
 		level.Error(logger).Log("err", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,58 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	_ "github.com/lib/pq"
+)
+
+// probeHandler opens a fresh connection to the target named in the `target`
+// query parameter, scrapes it into a dedicated registry via the collector
+// package, and closes the connection once the scrape completes. This
+// mirrors the multi-target pattern used by postgres_exporter so one process
+// can probe a whole fleet of PgBouncer instances instead of requiring a
+// sidecar per instance.
+func probeHandler(w http.ResponseWriter, r *http.Request, logger log.Logger, cfg *Config) {
+	target := r.URL.Query().Get("target")
+	if target == "" {
+		http.Error(w, "target parameter is missing", http.StatusBadRequest)
+		return
+	}
+
+	dsn, err := cfg.ResolveDSN(target, r.URL.Query().Get("auth_module"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error resolving target %q: %v", target, err), http.StatusBadRequest)
+		return
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error opening connection to target %q: %v", target, err), http.StatusInternalServerError)
+		return
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			level.Error(logger).Log("msg", "error closing probe connection", "target", target, "err", err)
+		}
+	}()
+
+	newHandler(db, logger).ServeHTTP(w, r)
+}
@@ -0,0 +1,118 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// rateLimiterIdleTTL is how long an IP's limiter is kept around after its
+// last request before it is evicted. Bounds the size of perIPRateLimiter's
+// map against an unbounded number of distinct callers.
+const rateLimiterIdleTTL = 10 * time.Minute
+
+// rateLimiterEvictInterval is how often expired limiters are swept out.
+const rateLimiterEvictInterval = time.Minute
+
+// perIPRateLimiter hands out a token-bucket rate.Limiter per remote IP,
+// creating one lazily on first use. It is used to bound how often a single
+// caller can hit the (expensive) /probe endpoint. Limiters idle for longer
+// than rateLimiterIdleTTL are evicted so the map does not grow unbounded
+// against a large or churning set of callers.
+type perIPRateLimiter struct {
+	mu       sync.Mutex
+	limiters map[string]*rateLimiterEntry
+	r        rate.Limit
+	burst    int
+}
+
+type rateLimiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newPerIPRateLimiter(ratePerSecond float64, burst int) *perIPRateLimiter {
+	l := &perIPRateLimiter{
+		limiters: make(map[string]*rateLimiterEntry),
+		r:        rate.Limit(ratePerSecond),
+		burst:    burst,
+	}
+	go l.evictIdle()
+
+	return l
+}
+
+func (l *perIPRateLimiter) allow(ip net.IP) bool {
+	key := ip.String()
+
+	l.mu.Lock()
+	entry, ok := l.limiters[key]
+	if !ok {
+		entry = &rateLimiterEntry{limiter: rate.NewLimiter(l.r, l.burst)}
+		l.limiters[key] = entry
+	}
+	entry.lastSeen = time.Now()
+	limiter := entry.limiter
+	l.mu.Unlock()
+
+	return limiter.Allow()
+}
+
+// evictIdle periodically removes limiters that have not been used in
+// rateLimiterIdleTTL. It runs for the lifetime of the process.
+func (l *perIPRateLimiter) evictIdle() {
+	ticker := time.NewTicker(rateLimiterEvictInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		l.evictOlderThan(time.Now().Add(-rateLimiterIdleTTL))
+	}
+}
+
+// evictOlderThan removes limiters last used before cutoff.
+func (l *perIPRateLimiter) evictOlderThan(cutoff time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for ip, entry := range l.limiters {
+		if entry.lastSeen.Before(cutoff) {
+			delete(l.limiters, ip)
+		}
+	}
+}
+
+// rateLimitMiddleware rejects requests over the per-IP rate with a 429 and a
+// Retry-After header. A nil limiter disables rate limiting entirely.
+func rateLimitMiddleware(next http.HandlerFunc, limiter *perIPRateLimiter, trustedProxies []*net.IPNet) http.HandlerFunc {
+	if limiter == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip, err := remoteIP(r, trustedProxies)
+		if err != nil || !limiter.allow(ip) {
+			w.Header().Set("Retry-After", strconv.Itoa(1))
+			http.Error(w, "Too Many Requests.", http.StatusTooManyRequests)
+			return
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPerIPRateLimiterAllow(t *testing.T) {
+	limiter := newPerIPRateLimiter(1, 1)
+
+	ip := net.ParseIP("203.0.113.5")
+	if !limiter.allow(ip) {
+		t.Fatal("first request should be allowed by a fresh limiter")
+	}
+	if limiter.allow(ip) {
+		t.Fatal("second immediate request should be rejected by a burst-1 limiter")
+	}
+
+	other := net.ParseIP("203.0.113.6")
+	if !other.Equal(net.ParseIP("203.0.113.6")) {
+		t.Fatal("sanity check on test IP")
+	}
+	if !limiter.allow(other) {
+		t.Fatal("a different IP should have its own independent bucket")
+	}
+}
+
+func TestPerIPRateLimiterEvictsIdleEntries(t *testing.T) {
+	limiter := newPerIPRateLimiter(1, 1)
+
+	ip := net.ParseIP("203.0.113.5")
+	limiter.allow(ip)
+
+	limiter.mu.Lock()
+	limiter.limiters[ip.String()].lastSeen = time.Now().Add(-2 * rateLimiterIdleTTL)
+	limiter.mu.Unlock()
+
+	limiter.evictOlderThan(time.Now().Add(-rateLimiterIdleTTL))
+
+	limiter.mu.Lock()
+	n := len(limiter.limiters)
+	limiter.mu.Unlock()
+
+	if n != 0 {
+		t.Fatalf("expected expired entry to be evicted, %d entries remain", n)
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	limiter := newPerIPRateLimiter(1, 1)
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	handler := rateLimitMiddleware(ok, limiter, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/probe", nil)
+	req.RemoteAddr = "203.0.113.5:12345"
+
+	first := httptest.NewRecorder()
+	handler(first, req)
+	if first.Code != http.StatusOK {
+		t.Fatalf("first request got status %d, want %d", first.Code, http.StatusOK)
+	}
+
+	second := httptest.NewRecorder()
+	handler(second, req)
+	if second.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request got status %d, want %d", second.Code, http.StatusTooManyRequests)
+	}
+
+	if nilLimiterHandler := rateLimitMiddleware(ok, nil, nil); nilLimiterHandler == nil {
+		t.Fatal("rateLimitMiddleware with a nil limiter should still return a usable handler")
+	}
+}